@@ -0,0 +1,130 @@
+/*
+Copyright 2024 winstontang
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package consistenthash
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// ErrBoundedLoadDisabled "ring was not created with WithBoundedLoad"
+var ErrBoundedLoadDisabled = errors.New("bounded load mode is not enabled, see WithBoundedLoad")
+
+// GetBounded is like Get but enforces a per-node load capLimit so that no
+// physical node carries more than ceil((1+epsilon)*average) in-flight keys,
+// per "consistent hashing with bounded loads". Only usable on a ring created
+// with WithBoundedLoad. The caller MUST invoke the returned release func
+// (typically via defer) once the request that borrowed the node completes.
+// 有界负载模式下的Get，调用方必须在请求结束后调用release释放计数
+func (m *HashRing) GetBounded(key string) (node string, release func(), err error) {
+	m.Lock()
+	defer m.Unlock()
+
+	if !m.boundedLoad {
+		return "", nil, ErrBoundedLoadDisabled
+	}
+
+	var physicalCount int
+	if m.use64 {
+		physicalCount = len(m.nodeToVnode64)
+	} else {
+		physicalCount = len(m.nodeToVnode)
+	}
+	if physicalCount == 0 {
+		return "", nil, ErrRingEmpty
+	}
+
+	capLimit := int64(math.Ceil(float64(m.totalInFlight+1) * (1 + m.epsilon) / float64(physicalCount)))
+
+	if m.use64 {
+		node, err = m.getBounded64Locked(key, capLimit)
+	} else {
+		node, err = m.getBoundedLocked(key, capLimit)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	m.load[node]++
+	m.totalInFlight++
+
+	released := false
+	release = func() {
+		m.Lock()
+		defer m.Unlock()
+		if released {
+			return
+		}
+		released = true
+		m.load[node]--
+		m.totalInFlight--
+	}
+	return node, release, nil
+}
+
+// getBoundedLocked finds the first node clockwise from key's hash whose load
+// is under capLimit. MUST Lock() before calling.
+func (m *HashRing) getBoundedLocked(key string, capLimit int64) (string, error) {
+	if len(m.vnodeToNode) == 0 {
+		return "", ErrRingEmpty
+	}
+
+	u32Hash := m.HashFunc([]byte(key))
+	idx := sort.Search(len(m.vNodes), func(i int) bool { return m.vNodes[i] >= u32Hash })
+	if idx == len(m.vNodes) {
+		idx = 0
+	}
+
+	for scanned := 0; scanned < len(m.vNodes); scanned++ {
+		node := m.vnodeToNode[m.vNodes[idx]]
+		if m.load[node] < capLimit {
+			return node, nil
+		}
+		idx++
+		if idx == len(m.vNodes) {
+			idx = 0
+		}
+	}
+	// every reachable node is at capacity, e.g. with a negative epsilon
+	return "", ErrNotEnoughNodes
+}
+
+// getBounded64Locked is the 64-bit counterpart of getBoundedLocked. MUST Lock() before calling.
+func (m *HashRing) getBounded64Locked(key string, capLimit int64) (string, error) {
+	if len(m.vnode64ToNode) == 0 {
+		return "", ErrRingEmpty
+	}
+
+	u64Hash := m.HashFunc64([]byte(key))
+	idx := sort.Search(len(m.vNodes64), func(i int) bool { return m.vNodes64[i] >= u64Hash })
+	if idx == len(m.vNodes64) {
+		idx = 0
+	}
+
+	for scanned := 0; scanned < len(m.vNodes64); scanned++ {
+		node := m.vnode64ToNode[m.vNodes64[idx]]
+		if m.load[node] < capLimit {
+			return node, nil
+		}
+		idx++
+		if idx == len(m.vNodes64) {
+			idx = 0
+		}
+	}
+	return "", ErrNotEnoughNodes
+}
@@ -0,0 +1,102 @@
+package consistenthash
+
+import (
+	"math"
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+func TestGetBoundedDisabled(t *testing.T) {
+	ring := New(100, nil)
+	if err := ring.Add("node1"); err != nil {
+		t.Fatalf("Add nodes failed: %v", err)
+	}
+	if _, _, err := ring.GetBounded("key1"); err != ErrBoundedLoadDisabled {
+		t.Errorf("GetBounded on a plain ring = %v, want ErrBoundedLoadDisabled", err)
+	}
+}
+
+func TestGetBoundedInvariant(t *testing.T) {
+	const (
+		numBoundedNodes = 5
+		epsilon         = 0.25
+		numKeys         = 2000
+	)
+
+	ring := New(100, nil, WithBoundedLoad(epsilon))
+	nodes := make([]string, 0, numBoundedNodes)
+	for i := 0; i < numBoundedNodes; i++ {
+		nodes = append(nodes, "node"+strconv.Itoa(i))
+	}
+	if err := ring.Add(nodes...); err != nil {
+		t.Fatalf("Add nodes failed: %v", err)
+	}
+
+	releases := make([]func(), 0, numKeys)
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < numKeys; i++ {
+		key := "key_" + strconv.Itoa(rnd.Int())
+		node, release, err := ring.GetBounded(key)
+		if err != nil {
+			t.Fatalf("GetBounded failed: %v", err)
+		}
+		if node == "" {
+			t.Fatalf("GetBounded returned empty node")
+		}
+		releases = append(releases, release)
+
+		avg := float64(i+1) / float64(numBoundedNodes)
+		maxLoad := int64(math.Ceil((1 + epsilon) * avg))
+		if ring.load[node] > maxLoad {
+			t.Fatalf("node %s load %d exceeds ceil((1+eps)*avg)=%d at key %d", node, ring.load[node], maxLoad, i)
+		}
+	}
+
+	for _, release := range releases {
+		release()
+	}
+	if ring.totalInFlight != 0 {
+		t.Errorf("totalInFlight after releasing all keys = %d, want 0", ring.totalInFlight)
+	}
+	for node, load := range ring.load {
+		if load != 0 {
+			t.Errorf("node %s load after releasing all keys = %d, want 0", node, load)
+		}
+	}
+}
+
+func TestGetBoundedReleaseIdempotent(t *testing.T) {
+	ring := New(100, nil, WithBoundedLoad(1.0))
+	if err := ring.Add("node1", "node2"); err != nil {
+		t.Fatalf("Add nodes failed: %v", err)
+	}
+
+	_, release, err := ring.GetBounded("key1")
+	if err != nil {
+		t.Fatalf("GetBounded failed: %v", err)
+	}
+	release()
+	release()
+	if ring.totalInFlight != 0 {
+		t.Errorf("totalInFlight after double release = %d, want 0", ring.totalInFlight)
+	}
+}
+
+func TestGetBoundedRemoveZeroesLoad(t *testing.T) {
+	ring := New(100, nil, WithBoundedLoad(1.0))
+	if err := ring.Add("node1", "node2"); err != nil {
+		t.Fatalf("Add nodes failed: %v", err)
+	}
+	if _, _, err := ring.GetBounded("key1"); err != nil {
+		t.Fatalf("GetBounded failed: %v", err)
+	}
+
+	ring.Remove("node1", "node2")
+	if len(ring.load) != 0 {
+		t.Errorf("load map after removing all nodes = %v, want empty", ring.load)
+	}
+	if ring.totalInFlight != 0 {
+		t.Errorf("totalInFlight after removing all nodes = %d, want 0", ring.totalInFlight)
+	}
+}
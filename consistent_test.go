@@ -10,7 +10,7 @@ import (
 )
 
 func TestHashRing(t *testing.T) {
-	ring := consistenthash.New(100, nil, nil) // 创建新的哈希环，使用100个虚拟节点副本，使用默认值的哈希函数
+	ring := consistenthash.New(100, nil) // 创建新的哈希环，使用100个虚拟节点副本，使用默认值的哈希函数
 
 	err := ring.Add("node1", "node2", "node3")
 	assert.NoError(t, err) // 检查是否没有错误
@@ -40,7 +40,6 @@ func TestHashRing(t *testing.T) {
 	err = ring.ResetAll(
 		50,  // 使用 50 个虚拟节点副本
 		nil, // 哈希函数保持不变
-		nil, // 哈希函数保持不变
 		"node1", "node2", "node3", "node4", "node5",
 	)
 	assert.NoError(t, err) // 检查是否没有错误
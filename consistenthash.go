@@ -70,13 +70,42 @@ type HashRing struct {
 	vNodes      U32Slice            // Sorted virtual node hash32
 	vnodeToNode map[uint32]string   // hash ring
 	nodeToVnode map[string]U32Slice // all physical nodes. Slice for delete performence
+	nodeWeight  map[string]uint32   // per-node weight, only set for weighted nodes
+
+	// 64-bit hash mode, only populated when the ring was created with New64/ResetAll64
+	use64         bool
+	HashFunc64    Hash64              // hash func for key and for node, 64-bit mode
+	vNodes64      U64Slice            // Sorted virtual node hash64
+	vnode64ToNode map[uint64]string   // hash ring, 64-bit mode
+	nodeToVnode64 map[string]U64Slice // all physical nodes, 64-bit mode
+
+	// bounded-load mode, only active when New/New64 is given WithBoundedLoad
+	boundedLoad   bool
+	epsilon       float64
+	load          map[string]int64 // in-flight key count per physical node
+	totalInFlight int64
 
 	sync.RWMutex
 }
 
+// Option configures optional HashRing behavior, applied by New/New64.
+// 可选配置项，由New/New64应用
+type Option func(*HashRing)
+
+// WithBoundedLoad opts the ring into "consistent hashing with bounded loads":
+// GetBounded will not route a key to a physical node whose in-flight count
+// already exceeds ceil((1+epsilon)*average).
+// 开启有界负载模式，GetBounded不会把key分配给已经超过ceil((1+epsilon)*平均值)的节点
+func WithBoundedLoad(epsilon float64) Option {
+	return func(m *HashRing) {
+		m.boundedLoad = true
+		m.epsilon = epsilon
+	}
+}
+
 // New creates a new hash ring. With default hash function crc32.
 // 创建hash环，默认hash函数为crc32.ChecksumIEEE
-func New(replicas uint16, hash Hash32) *HashRing {
+func New(replicas uint16, hash Hash32, opts ...Option) *HashRing {
 	hintCap := initVNodeCap
 	if int(replicas) > hintCap {
 		hintCap = int(replicas)
@@ -88,6 +117,8 @@ func New(replicas uint16, hash Hash32) *HashRing {
 		vNodes:      make([]uint32, 0, hintCap),
 		vnodeToNode: make(map[uint32]string, hintCap),
 		nodeToVnode: make(map[string]U32Slice, initPNodeCap),
+		nodeWeight:  make(map[string]uint32, initPNodeCap),
+		load:        make(map[string]int64, initPNodeCap),
 	}
 	// 强制修正错误输入
 	if m.replicas < miniReplicas {
@@ -96,6 +127,9 @@ func New(replicas uint16, hash Hash32) *HashRing {
 	if m.HashFunc == nil {
 		m.HashFunc = crc32.ChecksumIEEE
 	}
+	for _, opt := range opts {
+		opt(m)
+	}
 	return m
 }
 
@@ -118,6 +152,9 @@ func (m *HashRing) IsEmpty() bool {
 	m.RLock()
 	defer m.RUnlock()
 
+	if m.use64 {
+		return len(m.vnode64ToNode) == 0
+	}
 	return len(m.vnodeToNode) == 0
 }
 
@@ -138,6 +175,13 @@ func (m *HashRing) RingInfo() string {
 func (m *HashRing) Add(nodes ...string) error {
 	m.Lock()
 	defer m.Unlock()
+	if m.use64 {
+		if len(m.vnode64ToNode)+len(nodes)*int(m.replicas) > limitVNodes {
+			return ErrRingFull
+		}
+		m.add64(true, nodes...)
+		return nil
+	}
 	// too much nodes, return error
 	if len(m.vnodeToNode)+len(nodes)*int(m.replicas) > limitVNodes {
 		return ErrRingFull
@@ -194,6 +238,10 @@ func (m *HashRing) add(doSort bool, nodes ...string) {
 func (m *HashRing) Remove(nodes ...string) {
 	m.Lock()
 	defer m.Unlock()
+	if m.use64 {
+		m.remove64(true, nodes...)
+		return
+	}
 	m.remove(true, nodes...)
 }
 
@@ -213,6 +261,11 @@ func (m *HashRing) remove(doSort bool, nodes ...string) {
 			delete(m.vnodeToNode, vhash32)
 		}
 		delete(m.nodeToVnode, node)
+		delete(m.nodeWeight, node)
+		if m.boundedLoad {
+			m.totalInFlight -= m.load[node]
+			delete(m.load, node)
+		}
 	}
 	m.rebuildVNodeSlice(doSort)
 }
@@ -239,6 +292,9 @@ func (m *HashRing) Reset(nodes ...string) error {
 	if len(nodes)*int(m.replicas) > limitVNodes {
 		return ErrRingFull
 	}
+	if m.use64 {
+		return m.reset64(nodes...)
+	}
 	resetNodes := make(map[string]struct{}, len(nodes))
 	for _, node := range nodes {
 		resetNodes[node] = struct{}{}
@@ -277,9 +333,15 @@ func (m *HashRing) clear() {
 	m.vNodes = make([]uint32, 0, initVNodeCap)
 	m.vnodeToNode = make(map[uint32]string, initVNodeCap)
 	m.nodeToVnode = make(map[string]U32Slice, initPNodeCap)
+	m.nodeWeight = make(map[string]uint32, initPNodeCap)
+	m.load = make(map[string]int64, initPNodeCap)
+	m.totalInFlight = 0
+	m.clear64()
 }
 
 // ResetAll 重置
+// Bounded-load configuration set via WithBoundedLoad survives ResetAll;
+// only the node set, replicas and hash function are replaced.
 func (m *HashRing) ResetAll(replicas uint16, hash Hash32, nodes ...string) error {
 	m.Lock()
 	defer m.Unlock()
@@ -306,6 +368,14 @@ func (m *HashRing) Get(key string) (string, error) {
 	m.RLock()
 	defer m.RUnlock()
 
+	if m.use64 {
+		return m.get64(key)
+	}
+	return m.getLocked(key)
+}
+
+// getLocked is the 32-bit counterpart of get64. MUST RLock() before calling.
+func (m *HashRing) getLocked(key string) (string, error) {
 	if len(m.vnodeToNode) == 0 {
 		return "", ErrRingEmpty
 	}
@@ -35,13 +35,7 @@ func TestHashRing(t *testing.T) {
 	t.Logf("Node for key1 after removing node1: %s", node)
 
 	// 重置节点
-	nodeSet := make(NodeSet)
-	nodeSet["node1"] = struct{}{}
-	nodeSet["node2"] = struct{}{}
-	nodeSet["node3"] = struct{}{}
-	nodeSet["node4"] = struct{}{}
-
-	err = ring.Reset(nodeSet)
+	err = ring.Reset("node1", "node2", "node3", "node4")
 	if err != nil {
 		t.Errorf("Reset nodes failed: %v", err)
 	}
@@ -82,12 +76,11 @@ func BenchmarkGet(b *testing.B) {
 	hashRing := New(100, nil)
 
 	// 添加初始节点
-	initialNodes := make(NodeSet, numNodes)
+	initialNodes := make([]string, 0, numNodes)
 	for i := 0; i < numNodes; i++ {
-		key := "node" + strconv.Itoa(i)
-		initialNodes[key] = struct{}{}
+		initialNodes = append(initialNodes, "node"+strconv.Itoa(i))
 	}
-	err := hashRing.Reset(initialNodes)
+	err := hashRing.Reset(initialNodes...)
 	if err != nil {
 		b.Errorf("Error resetting nodes: %v", err)
 	}
@@ -107,7 +100,7 @@ func BenchmarkReset0(b *testing.B) {
 	hashRing := New(100, nil)
 
 	// 添加初始节点
-	initialNodes := make(NodeSet, numNodes)
+	initialNodes := make(map[string]struct{}, numNodes)
 	i := 0
 	for ; i < numNodes; i++ {
 		key := "node" + strconv.Itoa(i)
@@ -115,7 +108,7 @@ func BenchmarkReset0(b *testing.B) {
 	}
 	// 测试主循环
 	for ti := 0; ti < b.N; ti++ {
-		err := hashRing.Reset(initialNodes)
+		err := hashRing.Reset(nodeSetKeys(initialNodes)...)
 		if err != nil {
 			b.Errorf("Error resetting nodes: %v", err)
 		}
@@ -127,7 +120,7 @@ func BenchmarkReset(b *testing.B) {
 	hashRing := New(100, nil)
 
 	// 添加初始节点
-	initialNodes := make(NodeSet, numNodes)
+	initialNodes := make(map[string]struct{}, numNodes)
 	i := 0
 	for ; i < numNodes; i++ {
 		key := "node" + strconv.Itoa(i)
@@ -135,7 +128,7 @@ func BenchmarkReset(b *testing.B) {
 	}
 	// 测试主循环
 	for ti := 0; ti < b.N; ti++ {
-		err := hashRing.Reset(initialNodes)
+		err := hashRing.Reset(nodeSetKeys(initialNodes)...)
 		if err != nil {
 			b.Errorf("Error resetting nodes: %v", err)
 		}
@@ -147,6 +140,15 @@ func BenchmarkReset(b *testing.B) {
 	}
 }
 
+// nodeSetKeys collects the keys of a node set into the slice Reset expects.
+func nodeSetKeys(nodes map[string]struct{}) []string {
+	keys := make([]string, 0, len(nodes))
+	for node := range nodes {
+		keys = append(keys, node)
+	}
+	return keys
+}
+
 func BenchmarkResetAll(b *testing.B) {
 	hashRing := New(100, nil)
 
@@ -0,0 +1,106 @@
+/*
+Copyright 2024 winstontang
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package consistenthash
+
+import (
+	"errors"
+	"sort"
+)
+
+// ErrNotEnoughNodes "not enough distinct physical nodes on the ring"
+var ErrNotEnoughNodes = errors.New("not enough distinct physical nodes")
+
+// GetN walks clockwise from key's hash and returns the next n *distinct*
+// physical nodes, skipping duplicate virtual nodes belonging to the same
+// backend. Useful as a preference list for replicated cache/storage placement.
+// 获取key对应的接下来n个不同的物理节点，用于副本放置
+func (m *HashRing) GetN(key string, n int) ([]string, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	if n <= 0 {
+		return nil, nil
+	}
+	if m.use64 {
+		return m.getN64Locked(key, n)
+	}
+	return m.getNLocked(key, n)
+}
+
+// getNLocked is the 32-bit counterpart of GetN's body. MUST RLock() before calling.
+func (m *HashRing) getNLocked(key string, n int) ([]string, error) {
+	if len(m.vnodeToNode) == 0 {
+		return nil, ErrRingEmpty
+	}
+	if n > len(m.nodeToVnode) {
+		return nil, ErrNotEnoughNodes
+	}
+
+	u32Hash := m.HashFunc([]byte(key))
+	idx := sort.Search(len(m.vNodes), func(i int) bool { return m.vNodes[i] >= u32Hash })
+	if idx == len(m.vNodes) {
+		idx = 0
+	}
+
+	result := make([]string, 0, n)
+	seen := make(map[string]struct{}, n)
+	for scanned := 0; scanned < len(m.vNodes) && len(result) < n; scanned++ {
+		node := m.vnodeToNode[m.vNodes[idx]]
+		if _, ok := seen[node]; !ok {
+			seen[node] = struct{}{}
+			result = append(result, node)
+		}
+		idx++
+		if idx == len(m.vNodes) {
+			idx = 0
+		}
+	}
+
+	return result, nil
+}
+
+// getN64Locked is the 64-bit counterpart of GetN's body. MUST RLock() before calling.
+func (m *HashRing) getN64Locked(key string, n int) ([]string, error) {
+	if len(m.vnode64ToNode) == 0 {
+		return nil, ErrRingEmpty
+	}
+	if n > len(m.nodeToVnode64) {
+		return nil, ErrNotEnoughNodes
+	}
+
+	u64Hash := m.HashFunc64([]byte(key))
+	idx := sort.Search(len(m.vNodes64), func(i int) bool { return m.vNodes64[i] >= u64Hash })
+	if idx == len(m.vNodes64) {
+		idx = 0
+	}
+
+	result := make([]string, 0, n)
+	seen := make(map[string]struct{}, n)
+	for scanned := 0; scanned < len(m.vNodes64) && len(result) < n; scanned++ {
+		node := m.vnode64ToNode[m.vNodes64[idx]]
+		if _, ok := seen[node]; !ok {
+			seen[node] = struct{}{}
+			result = append(result, node)
+		}
+		idx++
+		if idx == len(m.vNodes64) {
+			idx = 0
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,83 @@
+package consistenthash
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestGetN(t *testing.T) {
+	ring := New(100, nil)
+	err := ring.Add("node1", "node2", "node3", "node4")
+	if err != nil {
+		t.Fatalf("Add nodes failed: %v", err)
+	}
+
+	nodes, err := ring.GetN("key1", 3)
+	if err != nil {
+		t.Fatalf("GetN failed: %v", err)
+	}
+	if len(nodes) != 3 {
+		t.Fatalf("GetN returned %d nodes, want 3", len(nodes))
+	}
+	seen := make(map[string]struct{}, len(nodes))
+	for _, node := range nodes {
+		if _, ok := seen[node]; ok {
+			t.Errorf("GetN returned duplicate node %q", node)
+		}
+		seen[node] = struct{}{}
+	}
+
+	// 请求数量超过物理节点数，应返回 ErrNotEnoughNodes
+	if _, err = ring.GetN("key1", 5); err != ErrNotEnoughNodes {
+		t.Errorf("GetN with n > node count = %v, want ErrNotEnoughNodes", err)
+	}
+
+	empty := New(100, nil)
+	if _, err = empty.GetN("key1", 1); err != ErrRingEmpty {
+		t.Errorf("GetN on empty ring = %v, want ErrRingEmpty", err)
+	}
+}
+
+func TestGetNConcurrent(t *testing.T) {
+	ring := New(100, nil)
+	nodes := make([]string, 0, 20)
+	for i := 0; i < 20; i++ {
+		nodes = append(nodes, "node"+strconv.Itoa(i))
+	}
+	if err := ring.Add(nodes...); err != nil {
+		t.Fatalf("Add nodes failed: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := "key_" + strconv.Itoa(i)
+			if _, err := ring.GetN(key, 3); err != nil {
+				t.Errorf("GetN failed: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func BenchmarkGetN(b *testing.B) {
+	ring := New(100, nil)
+	nodes := make([]string, 0, numNodes)
+	for i := 0; i < numNodes; i++ {
+		nodes = append(nodes, "node"+strconv.Itoa(i))
+	}
+	if err := ring.Add(nodes...); err != nil {
+		b.Fatalf("Add nodes failed: %v", err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		key := "key_" + strconv.Itoa(i)
+		if _, err := ring.GetN(key, 3); err != nil {
+			b.Fatalf("GetN failed: %v", err)
+		}
+	}
+}
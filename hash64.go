@@ -0,0 +1,308 @@
+/*
+Copyright 2024 winstontang
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package consistenthash
+
+import (
+	"hash/fnv"
+	"math"
+	"sort"
+)
+
+// Hash64 func with uint64 return, lets callers plug in xxhash, murmur3, fnv64, etc.
+// 输出为uint64的hash函数
+type Hash64 func(data []byte) uint64
+
+// U64Slice implement for sorting
+// 实现sort.Interface接口的Uint64Slice
+type U64Slice []uint64
+
+// Len returns the length of the uints array.
+func (x U64Slice) Len() int { return len(x) }
+
+// Less returns true if element i is less than element j.
+func (x U64Slice) Less(i, j int) bool { return x[i] < x[j] }
+
+// Swap exchanges elements i and j.
+func (x U64Slice) Swap(i, j int) { x[i], x[j] = x[j], x[i] }
+
+// defaultHash64 fnv-1a 64bit, used when New64/ResetAll64 is given a nil Hash64.
+// 默认的64位hash函数，fnv-1a
+func defaultHash64(data []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(data) //nolint:errcheck // hash.Hash.Write never returns an error
+	return h.Sum64()
+}
+
+// New64 creates a new hash ring in 64-bit hash mode. With default hash function fnv-1a 64.
+// 创建64位模式的hash环，默认hash函数为fnv-1a
+func New64(replicas uint16, hash Hash64, opts ...Option) *HashRing {
+	hintCap := initVNodeCap
+	if int(replicas) > hintCap {
+		hintCap = int(replicas)
+	}
+
+	m := &HashRing{
+		use64:         true,
+		HashFunc64:    hash,
+		replicas:      replicas,
+		vNodes64:      make(U64Slice, 0, hintCap),
+		vnode64ToNode: make(map[uint64]string, hintCap),
+		nodeToVnode64: make(map[string]U64Slice, initPNodeCap),
+		nodeToVnode:   make(map[string]U32Slice, initPNodeCap),
+		nodeWeight:    make(map[string]uint32, initPNodeCap),
+		load:          make(map[string]int64, initPNodeCap),
+	}
+	// 强制修正错误输入
+	if m.replicas < miniReplicas {
+		m.replicas = defaultReplicas
+	}
+	if m.HashFunc64 == nil {
+		m.HashFunc64 = defaultHash64
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// numHash64 big uint64 to small uint64
+// 把大整数hash到一个小整数，64位版本
+func numHash64(key, max uint64) uint64 {
+	const prime uint64 = 1099511628211
+	// 乘法有可能溢出导致环绕，但不影响逻辑
+	return (key * prime) % max
+}
+
+// add64 MUST Lock() before called
+// The doSort CANNOT be false, unless you are SURE DO THE SORT.
+func (m *HashRing) add64(doSort bool, nodes ...string) {
+	if len(nodes) == 0 {
+		return
+	}
+	segmentLen := uint64(math.MaxUint64) / uint64(m.replicas)
+	for _, node := range nodes {
+		// Ignored duplicate node
+		if _, ok := m.nodeToVnode64[node]; ok {
+			continue
+		}
+		// Add physical node
+		m.nodeToVnode64[node] = make(U64Slice, 0, m.replicas)
+		for ui := uint16(0); ui < m.replicas; ui++ {
+			segmentStart := segmentLen * uint64(ui)
+			vhash64 := m.HashFunc64([]byte(combKey(node, int(ui))))
+			segmentIdx := numHash64(vhash64, segmentLen)
+			vhash64 = segmentStart + segmentIdx
+			// 检查是否有hash冲突，有冲突重hash两次
+			if _, ok := m.vnode64ToNode[vhash64]; ok {
+				segmentIdx = numHash64(vhash64+1, segmentLen)
+				vhash64 = segmentStart + segmentIdx
+				if _, ok := m.vnode64ToNode[vhash64]; ok {
+					segmentIdx = numHash64(vhash64+1, segmentLen)
+					vhash64 = segmentStart + segmentIdx
+					if _, ok := m.vnode64ToNode[vhash64]; ok {
+						// 如果还是冲突则直接跳过，逻辑无影响，稍微对均衡性有影响
+						continue
+					}
+				}
+			}
+			m.vnode64ToNode[vhash64] = node
+			m.vNodes64 = append(m.vNodes64, vhash64)
+			m.nodeToVnode64[node] = append(m.nodeToVnode64[node], vhash64)
+		}
+	}
+	if doSort {
+		sort.Sort(m.vNodes64)
+	}
+}
+
+// remove64 MUST Lock() before calling
+// The doSort CANNOT be false, unless you are SURE DO THE SORT.
+func (m *HashRing) remove64(doSort bool, nodes ...string) {
+	if len(nodes) == 0 {
+		return
+	}
+	for _, node := range nodes {
+		// 检查是否存在，不存在则跳过
+		if _, ok := m.nodeToVnode64[node]; !ok {
+			continue
+		}
+		// 删除虚拟节点和映射关系
+		for _, vhash64 := range m.nodeToVnode64[node] {
+			delete(m.vnode64ToNode, vhash64)
+		}
+		delete(m.nodeToVnode64, node)
+		delete(m.nodeWeight, node)
+		if m.boundedLoad {
+			m.totalInFlight -= m.load[node]
+			delete(m.load, node)
+		}
+	}
+	m.rebuildVNodeSlice64(doSort)
+}
+
+// rebuildVNodeSlice64 重建虚拟节点切片，64位版本
+// MUST Lock() before called, doSort usually true
+func (m *HashRing) rebuildVNodeSlice64(doSort bool) {
+	// 直接复用现有内存，不新开辟内存
+	m.vNodes64 = m.vNodes64[0:0]
+	for k := range m.vnode64ToNode {
+		m.vNodes64 = append(m.vNodes64, k)
+	}
+	if doSort {
+		sort.Sort(m.vNodes64)
+	}
+}
+
+// reset64 MUST Lock() before calling, the 64-bit counterpart of Reset's body.
+func (m *HashRing) reset64(nodes ...string) error {
+	resetNodes := make(map[string]struct{}, len(nodes))
+	for _, node := range nodes {
+		resetNodes[node] = struct{}{}
+	}
+	// 遍历物理节点，看是否在reset列表里面，不在的删除
+	delNodes := make([]string, 0)
+	for ringNode := range m.nodeToVnode64 {
+		if _, exists := resetNodes[ringNode]; !exists {
+			delNodes = append(delNodes, ringNode)
+		}
+	}
+	// 只做一次排序，所以remove和add里面不排序
+	m.remove64(false, delNodes...)
+	// 对于reset列表里面的，看是否在环上，不在的添加
+	addNodes := make([]string, 0)
+	for node := range resetNodes {
+		if _, exists := m.nodeToVnode64[node]; !exists {
+			addNodes = append(addNodes, node)
+		}
+	}
+	// 只做一次排序，所以remove和add里面不排序
+	m.add64(false, addNodes...)
+	// MUST DO sort
+	if len(delNodes) > 0 || len(addNodes) > 0 {
+		sort.Sort(m.vNodes64)
+	}
+
+	return nil
+}
+
+// clear64 clears the 64-bit ring state, switching the ring back to 32-bit mode.
+// MUST Lock() before called
+func (m *HashRing) clear64() {
+	m.use64 = false
+	m.HashFunc64 = nil
+	m.vNodes64 = make(U64Slice, 0, initVNodeCap)
+	m.vnode64ToNode = make(map[uint64]string, initVNodeCap)
+	m.nodeToVnode64 = make(map[string]U64Slice, initPNodeCap)
+}
+
+// ResetAll64 重置为64位模式
+func (m *HashRing) ResetAll64(replicas uint16, hash Hash64, nodes ...string) error {
+	m.Lock()
+	defer m.Unlock()
+	// clear and reset hash functions
+	m.clear()
+	m.use64 = true
+	if replicas >= miniReplicas {
+		m.replicas = replicas
+	}
+	m.HashFunc64 = hash
+	if m.HashFunc64 == nil {
+		m.HashFunc64 = defaultHash64
+	}
+	// too much nodes, return error
+	if len(nodes)*int(m.replicas) > limitVNodes {
+		return ErrRingFull
+	}
+	m.add64(true, nodes...)
+
+	return nil
+}
+
+// get64 is the 64-bit counterpart of Get's body.
+// MUST RLock() before calling
+func (m *HashRing) get64(key string) (string, error) {
+	if len(m.vnode64ToNode) == 0 {
+		return "", ErrRingEmpty
+	}
+
+	u64Hash := m.HashFunc64([]byte(key))
+	// Binary search for appropriate replica.
+	idx := sort.Search(len(m.vNodes64), func(i int) bool { return m.vNodes64[i] >= u64Hash })
+
+	// Attention
+	if idx == len(m.vNodes64) {
+		idx = 0
+	}
+
+	return m.vnode64ToNode[m.vNodes64[idx]], nil
+}
+
+// DistributionStdDev returns the standard deviation of key counts per
+// physical node for the given keys, using the ring's currently configured
+// hash mode (32-bit or 64-bit). Lets callers evaluate how evenly their
+// chosen hash function spreads keys across nodes.
+// 计算给定keys在各物理节点上分布的标准差，便于评估所选hash函数的均衡性
+func (m *HashRing) DistributionStdDev(keys []string) float64 {
+	m.RLock()
+	defer m.RUnlock()
+
+	var nodeCount int
+	if m.use64 {
+		nodeCount = len(m.nodeToVnode64)
+	} else {
+		nodeCount = len(m.nodeToVnode)
+	}
+	if nodeCount == 0 {
+		return 0
+	}
+
+	counts := make(map[string]int, nodeCount)
+	for _, key := range keys {
+		var (
+			node string
+			err  error
+		)
+		if m.use64 {
+			node, err = m.get64(key)
+		} else {
+			node, err = m.getLocked(key)
+		}
+		if err != nil {
+			continue
+		}
+		counts[node]++
+	}
+
+	// 分母用注册的物理节点总数，没有命中任何key的节点按0计入方差，
+	// 否则极端不均衡（全部落在一个节点）反而会算出标准差0
+	total := 0
+	for _, c := range counts {
+		total += c
+	}
+	mean := float64(total) / float64(nodeCount)
+
+	var variance float64
+	for _, c := range counts {
+		d := float64(c) - mean
+		variance += d * d
+	}
+	// 没命中任何key的节点，偏差就是mean本身
+	variance += float64(nodeCount-len(counts)) * mean * mean
+	variance /= float64(nodeCount)
+
+	return math.Sqrt(variance)
+}
@@ -0,0 +1,92 @@
+package consistenthash
+
+import (
+	"hash/adler32"
+	"hash/crc32"
+	"hash/fnv"
+	"strconv"
+	"testing"
+)
+
+func TestNew64(t *testing.T) {
+	ring := New64(100, nil)
+
+	err := ring.Add("node1", "node2", "node3")
+	if err != nil {
+		t.Fatalf("Add nodes failed: %v", err)
+	}
+
+	node, err := ring.Get("key1")
+	if err != nil {
+		t.Fatalf("Get node failed: %v", err)
+	}
+	t.Logf("Node for key1: %s", node)
+
+	ring.Remove("node1")
+	if _, err = ring.Get("key1"); err != nil {
+		t.Errorf("Get node failed after remove: %v", err)
+	}
+
+	if err = ring.Reset("node1", "node2", "node3", "node4"); err != nil {
+		t.Fatalf("Reset failed: %v", err)
+	}
+	if _, err = ring.Get("key1"); err != nil {
+		t.Errorf("Get node failed after reset: %v", err)
+	}
+}
+
+func TestDistributionStdDev(t *testing.T) {
+	ring := New(100, nil)
+	if err := ring.Add("node1", "node2", "node3", "node4"); err != nil {
+		t.Fatalf("Add nodes failed: %v", err)
+	}
+
+	keys := make([]string, 0, 10000)
+	for i := 0; i < 10000; i++ {
+		keys = append(keys, "key_"+strconv.Itoa(i))
+	}
+
+	stdDev := ring.DistributionStdDev(keys)
+	if stdDev < 0 {
+		t.Errorf("DistributionStdDev = %v, want >= 0", stdDev)
+	}
+	t.Logf("stddev across 4 nodes for 10000 keys: %v", stdDev)
+}
+
+// fnv32a hashes data with the 32-bit FNV-1a algorithm.
+func fnv32a(data []byte) uint32 {
+	h := fnv.New32a()
+	h.Write(data) //nolint:errcheck
+	return h.Sum32()
+}
+
+// adler32Sum hashes data with adler32, for the CRC32/FNV/Adler32 comparison below.
+// xxhash is not benchmarked: it is a third-party package and go.mod does not require it.
+func adler32Sum(data []byte) uint32 {
+	return adler32.Checksum(data)
+}
+
+func benchmarkDistribution(b *testing.B, hash Hash32) {
+	ring := New(100, hash)
+	nodes := make([]string, 0, 10)
+	for i := 0; i < 10; i++ {
+		nodes = append(nodes, "node"+strconv.Itoa(i))
+	}
+	if err := ring.Add(nodes...); err != nil {
+		b.Fatalf("Add nodes failed: %v", err)
+	}
+
+	keys := make([]string, 0, 100000)
+	for i := 0; i < 100000; i++ {
+		keys = append(keys, "key_"+strconv.Itoa(i))
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ring.DistributionStdDev(keys)
+	}
+}
+
+func BenchmarkDistributionCRC32(b *testing.B)   { benchmarkDistribution(b, crc32.ChecksumIEEE) }
+func BenchmarkDistributionFNV(b *testing.B)     { benchmarkDistribution(b, fnv32a) }
+func BenchmarkDistributionAdler32(b *testing.B) { benchmarkDistribution(b, adler32Sum) }
@@ -0,0 +1,155 @@
+/*
+Copyright 2024 winstontang
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package consistenthash
+
+import "sync"
+
+// Hasher is the common surface both HashRing (virtual-node ring) and
+// JumpRing (jump consistent hash) satisfy, so callers can swap
+// implementations without changing call sites.
+// HashRing和JumpRing共同实现的接口，便于调用方替换实现
+type Hasher interface {
+	Add(nodes ...string) error
+	Remove(nodes ...string)
+	Get(key string) (string, error)
+	Reset(nodes ...string) error
+}
+
+var (
+	_ Hasher = (*HashRing)(nil)
+	_ Hasher = (*JumpRing)(nil)
+)
+
+// JumpRing implements Lamping-Veach jump consistent hash. Unlike HashRing it
+// keeps zero virtual-node memory and its lookup takes O(ln N) steps in the
+// number of physical nodes N instead of an O(log V) binary search over
+// virtual nodes, which matters once a ring holds thousands of vnodes.
+// The tradeoff: jump hash only supports append/shrink-at-tail, so Remove is
+// implemented as swap-with-tail, which remaps every key owned by the former
+// tail node (not just the removed node's keys) - non-minimal disruption,
+// unlike HashRing.Remove.
+// 基于Lamping-Veach跳跃一致性hash的实现，O(ln N)查找，零虚拟节点内存开销；
+// 代价是Remove采用与末尾节点互换的策略，disruption不是最小的
+type JumpRing struct {
+	HashFunc64 Hash64
+	nodes      []string       // stable node-index table, index -> node
+	nodeIndex  map[string]int // node -> index into nodes
+
+	sync.RWMutex
+}
+
+// NewJumpRing creates a new JumpRing. With default hash function fnv-1a 64.
+// 创建JumpRing，默认hash函数为fnv-1a
+func NewJumpRing(hash Hash64) *JumpRing {
+	j := &JumpRing{
+		HashFunc64: hash,
+		nodes:      make([]string, 0, initPNodeCap),
+		nodeIndex:  make(map[string]int, initPNodeCap),
+	}
+	if j.HashFunc64 == nil {
+		j.HashFunc64 = defaultHash64
+	}
+	return j
+}
+
+// jumpHash is Lamping-Veach jump consistent hash: given a 64-bit key hash
+// and numBuckets buckets, returns the bucket index key maps to.
+func jumpHash(key uint64, numBuckets int32) int32 {
+	var b, j int64 = -1, 0
+	for j < int64(numBuckets) {
+		b = j
+		key = key*2862933555777941757 + 1
+		j = int64(float64(b+1) * float64(int64(1)<<31) / float64((key>>33)+1))
+	}
+	return int32(b)
+}
+
+// Add appends nodes to the ring. Duplicate nodes are ignored.
+// 添加节点，已存在的节点会被忽略
+func (j *JumpRing) Add(nodes ...string) error {
+	j.Lock()
+	defer j.Unlock()
+
+	for _, node := range nodes {
+		if _, ok := j.nodeIndex[node]; ok {
+			continue
+		}
+		j.nodeIndex[node] = len(j.nodes)
+		j.nodes = append(j.nodes, node)
+	}
+	return nil
+}
+
+// Remove removes nodes from the ring via swap-with-tail: the removed slot is
+// filled with the current tail node, so the removed node's keys, which still
+// jump-hash to that bucket index, now go to the former tail node, while the
+// keys of the dropped last bucket spread over the remaining buckets. This
+// keeps bucket indices contiguous, which jump hash requires, at the cost of
+// non-minimal disruption.
+// 删除节点，采用与末尾节点互换的策略以保持bucket索引连续
+func (j *JumpRing) Remove(nodes ...string) {
+	j.Lock()
+	defer j.Unlock()
+
+	for _, node := range nodes {
+		idx, ok := j.nodeIndex[node]
+		if !ok {
+			continue
+		}
+		last := len(j.nodes) - 1
+		if idx != last {
+			tail := j.nodes[last]
+			j.nodes[idx] = tail
+			j.nodeIndex[tail] = idx
+		}
+		j.nodes = j.nodes[:last]
+		delete(j.nodeIndex, node)
+	}
+}
+
+// Reset replaces the ring's node set with nodes.
+// 重置节点集合
+func (j *JumpRing) Reset(nodes ...string) error {
+	j.Lock()
+	defer j.Unlock()
+
+	j.nodes = make([]string, 0, len(nodes))
+	j.nodeIndex = make(map[string]int, len(nodes))
+	for _, node := range nodes {
+		if _, ok := j.nodeIndex[node]; ok {
+			continue
+		}
+		j.nodeIndex[node] = len(j.nodes)
+		j.nodes = append(j.nodes, node)
+	}
+	return nil
+}
+
+// Get gets the node the provided key jump-hashes to.
+// 获取key对应的节点
+func (j *JumpRing) Get(key string) (string, error) {
+	j.RLock()
+	defer j.RUnlock()
+
+	if len(j.nodes) == 0 {
+		return "", ErrRingEmpty
+	}
+
+	h := j.HashFunc64([]byte(key))
+	b := jumpHash(h, int32(len(j.nodes)))
+	return j.nodes[b], nil
+}
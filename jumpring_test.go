@@ -0,0 +1,113 @@
+package consistenthash
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestJumpRing(t *testing.T) {
+	ring := NewJumpRing(nil)
+
+	if _, err := ring.Get("key1"); err != ErrRingEmpty {
+		t.Errorf("Get on empty ring = %v, want ErrRingEmpty", err)
+	}
+
+	if err := ring.Add("node1", "node2", "node3"); err != nil {
+		t.Fatalf("Add nodes failed: %v", err)
+	}
+
+	node, err := ring.Get("key1")
+	if err != nil {
+		t.Fatalf("Get node failed: %v", err)
+	}
+	t.Logf("Node for key1: %s", node)
+
+	ring.Remove("node1")
+	if len(ring.nodes) != 2 {
+		t.Errorf("node count after remove = %d, want 2", len(ring.nodes))
+	}
+	if _, err = ring.Get("key1"); err != nil {
+		t.Errorf("Get node failed after remove: %v", err)
+	}
+
+	if err = ring.Reset("node1", "node2", "node3", "node4"); err != nil {
+		t.Fatalf("Reset failed: %v", err)
+	}
+	if len(ring.nodes) != 4 {
+		t.Errorf("node count after reset = %d, want 4", len(ring.nodes))
+	}
+}
+
+func TestJumpRingStable(t *testing.T) {
+	// 相同的key在节点数不变时应始终落在同一个节点上
+	ring := NewJumpRing(nil)
+	nodes := make([]string, 0, 50)
+	for i := 0; i < 50; i++ {
+		nodes = append(nodes, "node"+strconv.Itoa(i))
+	}
+	if err := ring.Reset(nodes...); err != nil {
+		t.Fatalf("Reset failed: %v", err)
+	}
+
+	first, err := ring.Get("stable-key")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		node, err := ring.Get("stable-key")
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if node != first {
+			t.Errorf("Get(%q) = %s, want stable %s", "stable-key", node, first)
+		}
+	}
+}
+
+func jumpRingWithNodes(n int) *JumpRing {
+	ring := NewJumpRing(nil)
+	nodes := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		nodes = append(nodes, "node"+strconv.Itoa(i))
+	}
+	_ = ring.Add(nodes...)
+	return ring
+}
+
+func hashRingWithNodes(n int) *HashRing {
+	ring := New(100, nil)
+	nodes := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		nodes = append(nodes, "node"+strconv.Itoa(i))
+	}
+	_ = ring.Add(nodes...)
+	return ring
+}
+
+func benchmarkJumpRingGet(b *testing.B, n int) {
+	ring := jumpRingWithNodes(n)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ring.Get("key_" + strconv.Itoa(i)); err != nil {
+			b.Fatalf("Get failed: %v", err)
+		}
+	}
+}
+
+func benchmarkHashRingGet(b *testing.B, n int) {
+	ring := hashRingWithNodes(n)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ring.Get("key_" + strconv.Itoa(i)); err != nil {
+			b.Fatalf("Get failed: %v", err)
+		}
+	}
+}
+
+func BenchmarkJumpRingGet10(b *testing.B)   { benchmarkJumpRingGet(b, 10) }
+func BenchmarkJumpRingGet100(b *testing.B)  { benchmarkJumpRingGet(b, 100) }
+func BenchmarkJumpRingGet1000(b *testing.B) { benchmarkJumpRingGet(b, 1000) }
+
+func BenchmarkHashRingGet10(b *testing.B)   { benchmarkHashRingGet(b, 10) }
+func BenchmarkHashRingGet100(b *testing.B)  { benchmarkHashRingGet(b, 100) }
+func BenchmarkHashRingGet1000(b *testing.B) { benchmarkHashRingGet(b, 1000) }
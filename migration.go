@@ -0,0 +1,305 @@
+/*
+Copyright 2024 winstontang
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package consistenthash
+
+import "sort"
+
+// MigrationPlan reports how a proposed node set change moves keys from one
+// physical node to another, so operators can drain a cache before a deploy.
+// MigrationPlan报告一次节点变更会把key从哪个物理节点迁移到哪个物理节点
+type MigrationPlan struct {
+	movedFraction float64
+	perNodeDelta  map[string]int
+}
+
+// MovedFraction returns the fraction of the key space (0..1) that changes owner.
+func (p MigrationPlan) MovedFraction() float64 {
+	return p.movedFraction
+}
+
+// PerNodeDelta returns, per physical node, how much it gains (positive) or
+// loses (negative). In sampled mode (Diff called with sampleKeys) the unit is
+// number of sample keys. In exact mode (Diff called with no sampleKeys) the
+// unit is raw ring-width, out of 2^32 for a HashRing and 2^64 for a New64 ring.
+// PerNodeDelta返回每个物理节点增加/减少的份额；采样模式下单位是key数，精确模式下单位是环宽度
+func (p MigrationPlan) PerNodeDelta() map[string]int {
+	cp := make(map[string]int, len(p.perNodeDelta))
+	for node, delta := range p.perNodeDelta {
+		cp[node] = delta
+	}
+	return cp
+}
+
+// Diff reports, for a proposed change from m to other, which keys move from
+// which old node to which new node.
+//
+// If sampleKeys is non-empty, each key is located on both rings via Get and
+// the plan is built from the observed moves - cheap, and exact for the keys
+// given. If sampleKeys is empty, Diff instead walks the two rings' sorted
+// vNode ownership intervals directly and computes the exact moved fraction
+// and per-node delta over the whole ring, without needing to know the keys
+// in advance. This walk sorts the merged vNode boundaries and looks up each
+// interval's owner by binary search, so it costs O(V log V) with
+// V = V_old + V_new, not O(V). In this exact mode m and other must be the
+// same hash width (both New or both New64); otherwise Diff returns a plan
+// with no moves.
+// 比较m和other两个环，返回key迁移计划。sampleKeys非空时按采样key计算，
+// 为空时按vNode归属区间精确计算，此时m和other的hash位数必须一致
+func (m *HashRing) Diff(other *HashRing, sampleKeys []string) MigrationPlan {
+	if len(sampleKeys) > 0 {
+		return m.diffSampled(other, sampleKeys)
+	}
+	return m.diffExact(other)
+}
+
+func (m *HashRing) diffSampled(other *HashRing, sampleKeys []string) MigrationPlan {
+	plan := MigrationPlan{perNodeDelta: make(map[string]int)}
+
+	moved := 0
+	for _, key := range sampleKeys {
+		oldNode, errOld := m.Get(key)
+		newNode, errNew := other.Get(key)
+		if errOld != nil || errNew != nil {
+			continue
+		}
+		if oldNode == newNode {
+			continue
+		}
+		moved++
+		plan.perNodeDelta[oldNode]--
+		plan.perNodeDelta[newNode]++
+	}
+	plan.movedFraction = float64(moved) / float64(len(sampleKeys))
+
+	return plan
+}
+
+// ringSnapshot is a point-in-time copy of the vNode ownership state of one
+// ring, taken under that ring's own lock. Diffing two snapshots never needs
+// to hold both rings' locks at once, so it can't deadlock against concurrent
+// Add/Remove on either ring regardless of call order.
+type ringSnapshot struct {
+	use64         bool
+	vNodes        U32Slice
+	vnodeToNode   map[uint32]string
+	vNodes64      U64Slice
+	vnode64ToNode map[uint64]string
+}
+
+// snapshotForDiff copies the state diffExact needs while holding only this
+// ring's own RLock.
+func (m *HashRing) snapshotForDiff() ringSnapshot {
+	m.RLock()
+	defer m.RUnlock()
+
+	if m.use64 {
+		snap := ringSnapshot{
+			use64:         true,
+			vNodes64:      append(U64Slice(nil), m.vNodes64...),
+			vnode64ToNode: make(map[uint64]string, len(m.vnode64ToNode)),
+		}
+		for k, v := range m.vnode64ToNode {
+			snap.vnode64ToNode[k] = v
+		}
+		return snap
+	}
+	snap := ringSnapshot{
+		vNodes:      append(U32Slice(nil), m.vNodes...),
+		vnodeToNode: make(map[uint32]string, len(m.vnodeToNode)),
+	}
+	for k, v := range m.vnodeToNode {
+		snap.vnodeToNode[k] = v
+	}
+	return snap
+}
+
+func (m *HashRing) diffExact(other *HashRing) MigrationPlan {
+	// Snapshot each ring under its own lock, one at a time, so we never hold
+	// both rings' locks simultaneously - see ringSnapshot.
+	oldSnap := m.snapshotForDiff()
+	newSnap := other.snapshotForDiff()
+
+	plan := MigrationPlan{perNodeDelta: make(map[string]int)}
+	if oldSnap.use64 != newSnap.use64 {
+		// mismatched hash width, nothing meaningful to compare
+		return plan
+	}
+	if oldSnap.use64 {
+		return diffExact64Locked(oldSnap, newSnap)
+	}
+	return diffExact32Locked(oldSnap, newSnap)
+}
+
+// diffExact32Locked operates on immutable snapshots, no locking required.
+func diffExact32Locked(oldRing, newRing ringSnapshot) MigrationPlan {
+	plan := MigrationPlan{perNodeDelta: make(map[string]int)}
+
+	oldV, newV := oldRing.vNodes, newRing.vNodes
+	if len(oldV) == 0 && len(newV) == 0 {
+		return plan
+	}
+
+	boundaries := mergeSortedU32(oldV, newV)
+	const ringWidth = uint64(1) << 32
+
+	var movedWidth uint64
+	for i, start := range boundaries {
+		var end uint32
+		if i+1 < len(boundaries) {
+			end = boundaries[i+1]
+		} else {
+			end = boundaries[0]
+		}
+		width := ringWidth32Width(start, end)
+
+		oldOwner := ownerAtU32(oldV, oldRing.vnodeToNode, start)
+		newOwner := ownerAtU32(newV, newRing.vnodeToNode, start)
+		if oldOwner == newOwner {
+			continue
+		}
+		movedWidth += width
+		if oldOwner != "" {
+			plan.perNodeDelta[oldOwner] -= int(width)
+		}
+		if newOwner != "" {
+			plan.perNodeDelta[newOwner] += int(width)
+		}
+	}
+	plan.movedFraction = float64(movedWidth) / float64(ringWidth)
+
+	return plan
+}
+
+// diffExact64Locked operates on immutable snapshots, no locking required.
+func diffExact64Locked(oldRing, newRing ringSnapshot) MigrationPlan {
+	plan := MigrationPlan{perNodeDelta: make(map[string]int)}
+
+	oldV, newV := oldRing.vNodes64, newRing.vNodes64
+	if len(oldV) == 0 && len(newV) == 0 {
+		return plan
+	}
+
+	boundaries := mergeSortedU64(oldV, newV)
+
+	var movedWidth float64 // 2^64 overflows uint64 math, accumulate as float64
+	for i, start := range boundaries {
+		var end uint64
+		if i+1 < len(boundaries) {
+			end = boundaries[i+1]
+		} else {
+			end = boundaries[0]
+		}
+		width := ringWidth64Width(start, end)
+
+		oldOwner := ownerAtU64(oldV, oldRing.vnode64ToNode, start)
+		newOwner := ownerAtU64(newV, newRing.vnode64ToNode, start)
+		if oldOwner == newOwner {
+			continue
+		}
+		movedWidth += width
+		if oldOwner != "" {
+			plan.perNodeDelta[oldOwner] -= int(width)
+		}
+		if newOwner != "" {
+			plan.perNodeDelta[newOwner] += int(width)
+		}
+	}
+	plan.movedFraction = movedWidth / (1 << 64)
+
+	return plan
+}
+
+// mergeSortedU32 merges two already-sorted, deduplicated slices into one
+// sorted, deduplicated slice.
+func mergeSortedU32(a, b U32Slice) []uint32 {
+	seen := make(map[uint32]struct{}, len(a)+len(b))
+	merged := make([]uint32, 0, len(a)+len(b))
+	for _, v := range a {
+		if _, ok := seen[v]; !ok {
+			seen[v] = struct{}{}
+			merged = append(merged, v)
+		}
+	}
+	for _, v := range b {
+		if _, ok := seen[v]; !ok {
+			seen[v] = struct{}{}
+			merged = append(merged, v)
+		}
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i] < merged[j] })
+	return merged
+}
+
+func mergeSortedU64(a, b U64Slice) []uint64 {
+	seen := make(map[uint64]struct{}, len(a)+len(b))
+	merged := make([]uint64, 0, len(a)+len(b))
+	for _, v := range a {
+		if _, ok := seen[v]; !ok {
+			seen[v] = struct{}{}
+			merged = append(merged, v)
+		}
+	}
+	for _, v := range b {
+		if _, ok := seen[v]; !ok {
+			seen[v] = struct{}{}
+			merged = append(merged, v)
+		}
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i] < merged[j] })
+	return merged
+}
+
+// ringWidth32Width returns the width of [start, end) on a wrapping uint32 ring.
+func ringWidth32Width(start, end uint32) uint64 {
+	if end > start {
+		return uint64(end - start)
+	}
+	// wraps around the end of the ring
+	return uint64(^uint32(0)-start) + uint64(end) + 1
+}
+
+// ringWidth64Width returns the width of [start, end) on a wrapping uint64 ring.
+func ringWidth64Width(start, end uint64) float64 {
+	if end > start {
+		return float64(end - start)
+	}
+	return float64(^uint64(0)-start) + float64(end) + 1
+}
+
+// ownerAtU32 returns the node owning position pos on a ring with sorted
+// vNodes vNodes and ownership map owners. Returns "" if vNodes is empty.
+func ownerAtU32(vNodes U32Slice, owners map[uint32]string, pos uint32) string {
+	if len(vNodes) == 0 {
+		return ""
+	}
+	idx := sort.Search(len(vNodes), func(i int) bool { return vNodes[i] >= pos })
+	if idx == len(vNodes) {
+		idx = 0
+	}
+	return owners[vNodes[idx]]
+}
+
+func ownerAtU64(vNodes U64Slice, owners map[uint64]string, pos uint64) string {
+	if len(vNodes) == 0 {
+		return ""
+	}
+	idx := sort.Search(len(vNodes), func(i int) bool { return vNodes[i] >= pos })
+	if idx == len(vNodes) {
+		idx = 0
+	}
+	return owners[vNodes[idx]]
+}
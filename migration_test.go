@@ -0,0 +1,102 @@
+package consistenthash
+
+import (
+	"math"
+	"strconv"
+	"testing"
+)
+
+func buildRing(nodeCount int) *HashRing {
+	ring := New(100, nil)
+	nodes := make([]string, 0, nodeCount)
+	for i := 0; i < nodeCount; i++ {
+		nodes = append(nodes, "node"+strconv.Itoa(i))
+	}
+	_ = ring.Add(nodes...)
+	return ring
+}
+
+func TestDiffExactSingleNodeAdd(t *testing.T) {
+	const n = 10
+	oldRing := buildRing(n)
+	newRing := buildRing(n)
+	if err := newRing.Add("extra-node"); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	plan := oldRing.Diff(newRing, nil)
+
+	want := 1.0 / float64(n+1)
+	if diff := math.Abs(plan.MovedFraction() - want); diff > want*0.3 {
+		t.Errorf("MovedFraction() = %v, want ~%v", plan.MovedFraction(), want)
+	}
+
+	delta := plan.PerNodeDelta()
+	if delta["extra-node"] <= 0 {
+		t.Errorf("PerNodeDelta()[extra-node] = %d, want > 0", delta["extra-node"])
+	}
+	for node, d := range delta {
+		if node != "extra-node" && d > 0 {
+			t.Errorf("PerNodeDelta()[%s] = %d, want <= 0 (existing nodes should only lose share)", node, d)
+		}
+	}
+}
+
+func TestDiffExactSingleNodeRemove(t *testing.T) {
+	const n = 10
+	oldRing := buildRing(n)
+	newRing := buildRing(n)
+	newRing.Remove("node0")
+
+	plan := oldRing.Diff(newRing, nil)
+
+	want := 1.0 / float64(n)
+	if diff := math.Abs(plan.MovedFraction() - want); diff > want*0.3 {
+		t.Errorf("MovedFraction() = %v, want ~%v", plan.MovedFraction(), want)
+	}
+}
+
+func TestDiffExactIdentical(t *testing.T) {
+	oldRing := buildRing(5)
+	newRing := buildRing(5)
+
+	plan := oldRing.Diff(newRing, nil)
+	if plan.MovedFraction() != 0 {
+		t.Errorf("MovedFraction() for identical rings = %v, want 0", plan.MovedFraction())
+	}
+}
+
+func TestDiffSampled(t *testing.T) {
+	oldRing := buildRing(5)
+	newRing := buildRing(5)
+	newRing.Remove("node0")
+
+	keys := make([]string, 0, 2000)
+	for i := 0; i < 2000; i++ {
+		keys = append(keys, "key_"+strconv.Itoa(i))
+	}
+
+	plan := oldRing.Diff(newRing, keys)
+	if plan.MovedFraction() <= 0 || plan.MovedFraction() >= 1 {
+		t.Errorf("MovedFraction() = %v, want in (0, 1)", plan.MovedFraction())
+	}
+
+	total := 0
+	for _, d := range plan.PerNodeDelta() {
+		total += d
+	}
+	if total != 0 {
+		t.Errorf("PerNodeDelta() sums to %d, want 0 (moves are zero-sum)", total)
+	}
+}
+
+func TestDiffMismatchedHashWidth(t *testing.T) {
+	oldRing := buildRing(5)
+	newRing := New64(100, nil)
+	_ = newRing.Add("node0", "node1")
+
+	plan := oldRing.Diff(newRing, nil)
+	if plan.MovedFraction() != 0 || len(plan.PerNodeDelta()) != 0 {
+		t.Errorf("Diff across mismatched hash widths = %+v, want zero-value plan", plan)
+	}
+}
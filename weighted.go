@@ -0,0 +1,258 @@
+/*
+Copyright 2024 winstontang
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package consistenthash
+
+import (
+	"math"
+	"sort"
+)
+
+// minWeight the smallest accepted weight, weight 0 is corrected to this value
+// 最小权重，传0会被修正为该值
+const minWeight uint32 = 1
+
+// AddWeighted adds nodes with a per-node weight, each node contributing
+// weight*replicas virtual nodes instead of the ring's default replicas.
+// Nodes already present on the ring (added via Add or AddWeighted) are ignored.
+// 按权重添加节点，每个节点的虚拟节点数为 weight*replicas，已存在的节点会被忽略
+func (m *HashRing) AddWeighted(nodes map[string]uint32) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.use64 {
+		total := 0
+		for node, weight := range nodes {
+			if _, ok := m.nodeToVnode64[node]; ok {
+				continue
+			}
+			total += vnodeCount(weight, m.replicas)
+		}
+		if len(m.vnode64ToNode)+total > limitVNodes {
+			return ErrRingFull
+		}
+		m.addWeighted64(true, nodes)
+		return nil
+	}
+
+	total := 0
+	for node, weight := range nodes {
+		if _, ok := m.nodeToVnode[node]; ok {
+			continue
+		}
+		total += vnodeCount(weight, m.replicas)
+	}
+	if len(m.vnodeToNode)+total > limitVNodes {
+		return ErrRingFull
+	}
+	m.addWeighted(true, nodes)
+	return nil
+}
+
+// vnodeCount returns the virtual node count a weighted node contributes.
+// 计算加权节点应贡献的虚拟节点数
+func vnodeCount(weight uint32, replicas uint16) int {
+	if weight < minWeight {
+		weight = minWeight
+	}
+	return int(weight) * int(replicas)
+}
+
+// addWeighted MUST Lock() before called
+// The doSort CANNOT be false, unless you are SURE DO THE SORT.
+func (m *HashRing) addWeighted(doSort bool, nodes map[string]uint32) {
+	if len(nodes) == 0 {
+		return
+	}
+	for node, weight := range nodes {
+		// Ignored duplicate node
+		if _, ok := m.nodeToVnode[node]; ok {
+			continue
+		}
+		if weight < minWeight {
+			weight = minWeight
+		}
+		n := vnodeCount(weight, m.replicas)
+		segmentLen := uint32(math.MaxUint32 / n)
+		// Add physical node
+		m.nodeToVnode[node] = make(U32Slice, 0, n)
+		m.nodeWeight[node] = weight
+		for ui := 0; ui < n; ui++ {
+			segmentStart := segmentLen * uint32(ui)
+			vhash32 := m.HashFunc([]byte(combKey(node, ui)))
+			segmentIdx := numHash(vhash32, segmentLen)
+			vhash32 = segmentStart + segmentIdx
+			// 检查是否有hash冲突，有冲突重hash两次
+			if _, ok := m.vnodeToNode[vhash32]; ok {
+				segmentIdx = numHash(vhash32+1, segmentLen)
+				vhash32 = segmentStart + segmentIdx
+				if _, ok := m.vnodeToNode[vhash32]; ok {
+					segmentIdx = numHash(vhash32+1, segmentLen)
+					vhash32 = segmentStart + segmentIdx
+					if _, ok := m.vnodeToNode[vhash32]; ok {
+						// 如果还是冲突则直接跳过，逻辑无影响，稍微对均衡性有影响
+						continue
+					}
+				}
+			}
+			m.vnodeToNode[vhash32] = node
+			m.vNodes = append(m.vNodes, vhash32)
+			m.nodeToVnode[node] = append(m.nodeToVnode[node], vhash32)
+		}
+	}
+	if doSort {
+		sort.Sort(m.vNodes)
+	}
+}
+
+// addWeighted64 is the 64-bit counterpart of addWeighted. MUST Lock() before called.
+// The doSort CANNOT be false, unless you are SURE DO THE SORT.
+func (m *HashRing) addWeighted64(doSort bool, nodes map[string]uint32) {
+	if len(nodes) == 0 {
+		return
+	}
+	for node, weight := range nodes {
+		// Ignored duplicate node
+		if _, ok := m.nodeToVnode64[node]; ok {
+			continue
+		}
+		if weight < minWeight {
+			weight = minWeight
+		}
+		n := vnodeCount(weight, m.replicas)
+		segmentLen := uint64(math.MaxUint64) / uint64(n)
+		// Add physical node
+		m.nodeToVnode64[node] = make(U64Slice, 0, n)
+		m.nodeWeight[node] = weight
+		for ui := 0; ui < n; ui++ {
+			segmentStart := segmentLen * uint64(ui)
+			vhash64 := m.HashFunc64([]byte(combKey(node, ui)))
+			segmentIdx := numHash64(vhash64, segmentLen)
+			vhash64 = segmentStart + segmentIdx
+			// 检查是否有hash冲突，有冲突重hash两次
+			if _, ok := m.vnode64ToNode[vhash64]; ok {
+				segmentIdx = numHash64(vhash64+1, segmentLen)
+				vhash64 = segmentStart + segmentIdx
+				if _, ok := m.vnode64ToNode[vhash64]; ok {
+					segmentIdx = numHash64(vhash64+1, segmentLen)
+					vhash64 = segmentStart + segmentIdx
+					if _, ok := m.vnode64ToNode[vhash64]; ok {
+						// 如果还是冲突则直接跳过，逻辑无影响，稍微对均衡性有影响
+						continue
+					}
+				}
+			}
+			m.vnode64ToNode[vhash64] = node
+			m.vNodes64 = append(m.vNodes64, vhash64)
+			m.nodeToVnode64[node] = append(m.nodeToVnode64[node], vhash64)
+		}
+	}
+	if doSort {
+		sort.Sort(m.vNodes64)
+	}
+}
+
+// ResetWeighted resets the ring to exactly the given weighted node set.
+// Nodes missing from nodes are removed; nodes whose weight actually changed
+// are re-inserted so their virtual node count matches the new weight; nodes
+// whose weight is unchanged are left untouched.
+// If return error，MUST ResetAll hashring, typically by adjusting the replicas!
+// 按权重重置节点集合，只有权重变化的节点会被重新插入
+// 返回错误，必须接收和处理
+func (m *HashRing) ResetWeighted(nodes map[string]uint32) error {
+	m.Lock()
+	defer m.Unlock()
+
+	total := 0
+	for _, weight := range nodes {
+		total += vnodeCount(weight, m.replicas)
+	}
+	if total > limitVNodes {
+		return ErrRingFull
+	}
+
+	if m.use64 {
+		ringNodes := make([]string, 0, len(m.nodeToVnode64))
+		for node := range m.nodeToVnode64 {
+			ringNodes = append(ringNodes, node)
+		}
+		delNodes, addNodes := m.diffWeighted(nodes, ringNodes)
+		// 只做一次排序，所以remove和addWeighted64里面不排序
+		m.remove64(false, delNodes...)
+		m.addWeighted64(false, addNodes)
+		if len(delNodes) > 0 || len(addNodes) > 0 {
+			sort.Sort(m.vNodes64)
+		}
+		return nil
+	}
+
+	ringNodes := make([]string, 0, len(m.nodeToVnode))
+	for node := range m.nodeToVnode {
+		ringNodes = append(ringNodes, node)
+	}
+	delNodes, addNodes := m.diffWeighted(nodes, ringNodes)
+	// 只做一次排序，所以remove和addWeighted里面不排序
+	m.remove(false, delNodes...)
+	m.addWeighted(false, addNodes)
+	if len(delNodes) > 0 || len(addNodes) > 0 {
+		sort.Sort(m.vNodes)
+	}
+
+	return nil
+}
+
+// diffWeighted computes which currently-ringed nodes should be dropped and
+// which (node, weight) pairs should be (re)inserted for a ResetWeighted call.
+// ringNodes is every physical node currently on the ring (nodeToVnode or
+// nodeToVnode64, depending on hash mode) - not just ones added via
+// AddWeighted - so nodes added via plain Add/Reset are also candidates for
+// removal when they're missing from nodes.
+func (m *HashRing) diffWeighted(nodes map[string]uint32, ringNodes []string) ([]string, map[string]uint32) {
+	// 遍历环上实际的物理节点，不在reset列表里面的删除
+	onRing := make(map[string]struct{}, len(ringNodes))
+	delNodes := make([]string, 0)
+	for _, ringNode := range ringNodes {
+		onRing[ringNode] = struct{}{}
+		if _, exists := nodes[ringNode]; !exists {
+			delNodes = append(delNodes, ringNode)
+		}
+	}
+
+	// 权重变化（或节点尚未在环上）的节点，先删除再重新插入；权重不变的跳过
+	addNodes := make(map[string]uint32, len(nodes))
+	for node, weight := range nodes {
+		if weight < minWeight {
+			weight = minWeight
+		}
+		_, isOnRing := onRing[node]
+		current, weighted := m.nodeWeight[node]
+		switch {
+		case !isOnRing:
+			// brand new node
+		case weighted && current == weight:
+			// weight unchanged, leave its vnodes untouched
+			continue
+		case !weighted && weight == minWeight:
+			// unweighted node already carries the implicit weight of 1
+			continue
+		}
+		if isOnRing {
+			delNodes = append(delNodes, node)
+		}
+		addNodes[node] = weight
+	}
+	return delNodes, addNodes
+}
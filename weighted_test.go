@@ -0,0 +1,124 @@
+package consistenthash
+
+import (
+	"testing"
+)
+
+func TestAddWeighted(t *testing.T) {
+	ring := New(100, nil)
+
+	// 按权重添加节点，node2 的虚拟节点数应为 node1 的两倍
+	err := ring.AddWeighted(map[string]uint32{
+		"node1": 1,
+		"node2": 2,
+	})
+	if err != nil {
+		t.Fatalf("AddWeighted failed: %v", err)
+	}
+
+	if got := len(ring.nodeToVnode["node1"]); got != 100 {
+		t.Errorf("node1 vnode count = %d, want 100", got)
+	}
+	if got := len(ring.nodeToVnode["node2"]); got != 200 {
+		t.Errorf("node2 vnode count = %d, want 200", got)
+	}
+
+	node, err := ring.Get("key1")
+	if err != nil {
+		t.Errorf("Get node failed: %v", err)
+	}
+	t.Logf("Node for key1: %s", node)
+}
+
+func TestResetWeighted(t *testing.T) {
+	ring := New(100, nil)
+
+	err := ring.AddWeighted(map[string]uint32{
+		"node1": 1,
+		"node2": 2,
+	})
+	if err != nil {
+		t.Fatalf("AddWeighted failed: %v", err)
+	}
+	node2Vnodes := append(U32Slice{}, ring.nodeToVnode["node2"]...)
+
+	// 只修改 node1 的权重，node2 权重不变，其虚拟节点不应被重新插入
+	err = ring.ResetWeighted(map[string]uint32{
+		"node1": 3,
+		"node2": 2,
+	})
+	if err != nil {
+		t.Fatalf("ResetWeighted failed: %v", err)
+	}
+
+	if got := len(ring.nodeToVnode["node1"]); got != 300 {
+		t.Errorf("node1 vnode count after reset = %d, want 300", got)
+	}
+	if got := ring.nodeToVnode["node2"]; !u32SliceEqual(got, node2Vnodes) {
+		t.Errorf("node2 vnodes changed even though its weight did not: got %v, want %v", got, node2Vnodes)
+	}
+
+	// node2 从列表中移除，应从环上删除
+	err = ring.ResetWeighted(map[string]uint32{
+		"node1": 3,
+	})
+	if err != nil {
+		t.Fatalf("ResetWeighted failed: %v", err)
+	}
+	if _, ok := ring.nodeToVnode["node2"]; ok {
+		t.Errorf("node2 should have been removed from the ring")
+	}
+}
+
+func TestResetWeightedRemovesPlainAddedNode(t *testing.T) {
+	ring := New(100, nil)
+
+	if err := ring.Add("plainNode"); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := ring.AddWeighted(map[string]uint32{"node1": 1}); err != nil {
+		t.Fatalf("AddWeighted failed: %v", err)
+	}
+
+	// plainNode 不在 ResetWeighted 的列表里面，即使它不是通过AddWeighted加入的，
+	// 也应该被删除
+	if err := ring.ResetWeighted(map[string]uint32{"node1": 1}); err != nil {
+		t.Fatalf("ResetWeighted failed: %v", err)
+	}
+	if _, ok := ring.nodeToVnode["plainNode"]; ok {
+		t.Errorf("plainNode should have been removed by ResetWeighted")
+	}
+}
+
+func TestResetWeighted64RemovesPlainAddedNode(t *testing.T) {
+	ring := New64(100, nil)
+
+	if err := ring.Add("plainNode"); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := ring.AddWeighted(map[string]uint32{"node1": 1}); err != nil {
+		t.Fatalf("AddWeighted failed: %v", err)
+	}
+
+	if err := ring.ResetWeighted(map[string]uint32{"node1": 1}); err != nil {
+		t.Fatalf("ResetWeighted failed: %v", err)
+	}
+	if _, ok := ring.nodeToVnode64["plainNode"]; ok {
+		t.Errorf("plainNode should have been removed by ResetWeighted")
+	}
+	if _, ok := ring.nodeToVnode64["node1"]; !ok {
+		t.Errorf("node1 should still be on the ring")
+	}
+}
+
+func u32SliceEqual(a, b U32Slice) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}